@@ -0,0 +1,83 @@
+// Command onion-explode reads a consolidated result file produced by
+// oniongen's -output-file mode and writes out a standard Tor hidden
+// service directory (hostname, hs_ed25519_public_key,
+// hs_ed25519_secret_key) for each line.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/n0madic/oniongen-go/internal/resultfile"
+	"github.com/n0madic/oniongen-go/pkg/okey"
+)
+
+func main() {
+	fs := flag.NewFlagSet("onion-explode", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: onion-explode [flags] <result-file>")
+		fs.PrintDefaults()
+	}
+	outputDir := fs.String("output-dir", ".", "directory under which exploded key directories are written")
+	_ = fs.Parse(os.Args[1:])
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	if err := explode(fs.Arg(0), *outputDir); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// explode reads each line of the result file at path and writes the
+// corresponding Tor key directory under outputDir.
+func explode(path, outputDir string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	count := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		count++
+
+		_, hostname, pub, secretKey, err := resultfile.ParseLine(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		dir := filepath.Join(outputDir, hostname)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("creating %s: %w", dir, err)
+		}
+		if err := okey.SaveSecretKey(filepath.Join(dir, "hs_ed25519_secret_key"), secretKey); err != nil {
+			return fmt.Errorf("writing secret key for %s: %w", hostname, err)
+		}
+		if err := okey.SavePublicKey(filepath.Join(dir, "hs_ed25519_public_key"), pub); err != nil {
+			return fmt.Errorf("writing public key for %s: %w", hostname, err)
+		}
+		if err := okey.SaveHostname(filepath.Join(dir, "hostname"), hostname); err != nil {
+			return fmt.Errorf("writing hostname for %s: %w", hostname, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	fmt.Printf("Exploded %d address(es) into %s\n", count, outputDir)
+	return nil
+}
@@ -0,0 +1,208 @@
+// Package match implements fast prefix and suffix matching for onion
+// addresses, as an alternative to compiling them down to regular
+// expressions.
+package match
+
+import (
+	"encoding/base32"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	base32Alphabet = "abcdefghijklmnopqrstuvwxyz234567"
+	maxAddressLen  = 56 // length of a v3 onion address without ".onion"
+	pubKeyBits     = 32 * 8
+)
+
+// ValidateBase32 reports an error if s contains any character outside the
+// Tor onion-address base32 alphabet (a-z, 2-7).
+func ValidateBase32(s string) error {
+	for _, r := range s {
+		if !strings.ContainsRune(base32Alphabet, r) {
+			return fmt.Errorf("match: invalid base32 character %q", r)
+		}
+	}
+	return nil
+}
+
+// Prefix matches onion addresses starting with a fixed, lowercase base32
+// pattern. When the pattern is short enough to fall entirely within the
+// first 32 bytes of the address payload (the public key itself), the
+// checksum and version byte don't affect it, so candidates can be rejected
+// by comparing raw public key bytes via MatchesPublicKey, without running
+// the SHA3 checksum needed to build the full address.
+type Prefix struct {
+	pattern  string
+	keyBits  []byte // pattern bits, left-aligned, padded to a byte boundary
+	bitLen   int    // number of significant leading bits in keyBits
+	fastPath bool   // true when bitLen fits within the public key's bits
+}
+
+// NewPrefix validates pattern and builds a Prefix matcher for it.
+func NewPrefix(pattern string) (*Prefix, error) {
+	pattern = strings.ToLower(pattern)
+	if len(pattern) == 0 || len(pattern) > maxAddressLen {
+		return nil, fmt.Errorf("match: prefix length must be between 1 and %d characters", maxAddressLen)
+	}
+	if err := ValidateBase32(pattern); err != nil {
+		return nil, err
+	}
+
+	p := &Prefix{pattern: pattern}
+	p.bitLen = len(pattern) * 5
+	if p.bitLen <= pubKeyBits {
+		bits, err := patternToBits(pattern)
+		if err != nil {
+			return nil, err
+		}
+		p.keyBits = bits
+		p.fastPath = true
+	}
+	return p, nil
+}
+
+// MatchesPublicKey performs the cheap pre-checksum rejection test. It
+// returns true when pub is still a possible match, or when the pattern is
+// too long for the fast path to apply (in which case MatchesAddress is the
+// only authoritative check).
+func (p *Prefix) MatchesPublicKey(pub []byte) bool {
+	if !p.fastPath {
+		return true
+	}
+	return bitsEqual(pub, p.keyBits, p.bitLen)
+}
+
+// MatchesAddress is the authoritative check against the final encoded
+// address.
+func (p *Prefix) MatchesAddress(addr string) bool {
+	return strings.HasPrefix(addr, p.pattern)
+}
+
+// Suffix matches onion addresses ending with a fixed, lowercase base32
+// pattern. The trailing bytes of the address payload depend on the
+// checksum and version byte, which are only known once SHA3 has run, so
+// unlike Prefix there is no pre-checksum fast path. It still bypasses the
+// regexp engine in favor of a plain string comparison.
+type Suffix struct {
+	pattern string
+}
+
+// NewSuffix validates pattern and builds a Suffix matcher for it.
+func NewSuffix(pattern string) (*Suffix, error) {
+	pattern = strings.ToLower(pattern)
+	if len(pattern) == 0 || len(pattern) > maxAddressLen {
+		return nil, fmt.Errorf("match: suffix length must be between 1 and %d characters", maxAddressLen)
+	}
+	if err := ValidateBase32(pattern); err != nil {
+		return nil, err
+	}
+	return &Suffix{pattern: pattern}, nil
+}
+
+// MatchesAddress reports whether addr ends with the suffix pattern.
+func (s *Suffix) MatchesAddress(addr string) bool {
+	return strings.HasSuffix(addr, s.pattern)
+}
+
+// Set bundles prefix, suffix and regexp patterns together and matches a
+// candidate against all of them with OR semantics, the same as the
+// original implementation that tried each pattern in turn.
+type Set struct {
+	prefixes     []*Prefix
+	suffixes     []*Suffix
+	regexps      []*regexp.Regexp
+	fastPrefixes []*Prefix
+	fastPathOnly bool
+}
+
+// NewSet builds a Set from already-validated prefix, suffix and regexp
+// matchers.
+func NewSet(prefixes []*Prefix, suffixes []*Suffix, regexps []*regexp.Regexp) *Set {
+	s := &Set{prefixes: prefixes, suffixes: suffixes, regexps: regexps}
+	for _, p := range prefixes {
+		if p.fastPath {
+			s.fastPrefixes = append(s.fastPrefixes, p)
+		}
+	}
+	s.fastPathOnly = len(suffixes) == 0 && len(regexps) == 0 &&
+		len(prefixes) > 0 && len(s.fastPrefixes) == len(prefixes)
+	return s
+}
+
+// MayMatchPublicKey is a cheap pre-checksum filter. When every configured
+// pattern is a prefix short enough to rely solely on public key bytes, it
+// rejects candidates that cannot possibly match before the SHA3 checksum
+// is computed. Otherwise (suffixes, regexps, or long prefixes are in play)
+// it always returns true, deferring to MatchesAddress.
+func (s *Set) MayMatchPublicKey(pub []byte) bool {
+	if !s.fastPathOnly {
+		return true
+	}
+	for _, p := range s.fastPrefixes {
+		if p.MatchesPublicKey(pub) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesAddress reports whether addr satisfies any configured pattern.
+func (s *Set) MatchesAddress(addr string) bool {
+	for _, p := range s.prefixes {
+		if p.MatchesAddress(addr) {
+			return true
+		}
+	}
+	for _, suf := range s.suffixes {
+		if suf.MatchesAddress(addr) {
+			return true
+		}
+	}
+	for _, re := range s.regexps {
+		if re.MatchString(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// patternToBits decodes a base32 pattern into its left-aligned bit
+// representation: the returned bytes hold the pattern's bits starting at
+// the most significant bit and padded with zero bits, sized to hold
+// len(pattern)*5 bits.
+func patternToBits(pattern string) ([]byte, error) {
+	bitLen := len(pattern) * 5
+	byteLen := (bitLen + 7) / 8
+
+	padded := pattern + strings.Repeat("a", (8-len(pattern)%8)%8)
+	decoded, err := base32.StdEncoding.DecodeString(strings.ToUpper(padded))
+	if err != nil {
+		return nil, fmt.Errorf("match: decoding pattern: %w", err)
+	}
+	return decoded[:byteLen], nil
+}
+
+// bitsEqual reports whether the leading bitLen bits of data match pattern.
+func bitsEqual(data, pattern []byte, bitLen int) bool {
+	fullBytes := bitLen / 8
+	if len(data) < fullBytes {
+		return false
+	}
+	for i := 0; i < fullBytes; i++ {
+		if data[i] != pattern[i] {
+			return false
+		}
+	}
+
+	remBits := bitLen % 8
+	if remBits == 0 {
+		return true
+	}
+	if len(data) <= fullBytes {
+		return false
+	}
+	mask := byte(0xFF << (8 - remBits))
+	return data[fullBytes]&mask == pattern[fullBytes]&mask
+}
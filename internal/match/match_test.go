@@ -0,0 +1,118 @@
+package match
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/n0madic/oniongen-go/pkg/oaddr"
+)
+
+func TestPrefixFastPathAgreesWithAddress(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	addr := oaddr.Encode(pub)
+
+	for _, n := range []int{1, 5, 20, 51} {
+		p, err := NewPrefix(addr[:n])
+		if err != nil {
+			t.Fatalf("NewPrefix(%q) error = %v", addr[:n], err)
+		}
+		if !p.fastPath {
+			t.Fatalf("NewPrefix(%q): expected fast path for length %d", addr[:n], n)
+		}
+		if !p.MatchesPublicKey(pub) {
+			t.Fatalf("MatchesPublicKey() = false for the key that produced the address")
+		}
+		if !p.MatchesAddress(addr) {
+			t.Fatalf("MatchesAddress() = false for the key that produced the address")
+		}
+	}
+}
+
+func TestPrefixFastPathRejectsMismatch(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	other, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	addr := oaddr.Encode(pub)
+
+	p, err := NewPrefix(addr[:10])
+	if err != nil {
+		t.Fatalf("NewPrefix() error = %v", err)
+	}
+	if oaddr.Encode(other)[:10] == addr[:10] {
+		t.Fatal("test setup produced a collision, rerun")
+	}
+	if p.MatchesPublicKey(other) {
+		t.Fatal("MatchesPublicKey() = true for a key whose address does not share the prefix")
+	}
+}
+
+func TestNewPrefixRejectsInvalidInput(t *testing.T) {
+	if _, err := NewPrefix(""); err == nil {
+		t.Fatal("NewPrefix(\"\") returned no error")
+	}
+	if _, err := NewPrefix("0notbase32"); err == nil {
+		t.Fatal("NewPrefix() accepted non-base32 characters")
+	}
+	long := make([]byte, maxAddressLen+1)
+	for i := range long {
+		long[i] = 'a'
+	}
+	if _, err := NewPrefix(string(long)); err == nil {
+		t.Fatal("NewPrefix() accepted a pattern longer than an address")
+	}
+}
+
+func TestSetMayMatchPublicKey(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	addr := oaddr.Encode(pub)
+
+	prefix, err := NewPrefix(addr[:8])
+	if err != nil {
+		t.Fatalf("NewPrefix() error = %v", err)
+	}
+	set := NewSet([]*Prefix{prefix}, nil, nil)
+	if !set.MayMatchPublicKey(pub) {
+		t.Fatal("MayMatchPublicKey() = false for a key that matches the prefix")
+	}
+	if !set.MatchesAddress(addr) {
+		t.Fatal("MatchesAddress() = false for a key that matches the prefix")
+	}
+
+	other, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	if oaddr.Encode(other)[:8] == addr[:8] {
+		t.Skip("test setup produced a collision, rerun")
+	}
+	if set.MayMatchPublicKey(other) {
+		t.Fatal("MayMatchPublicKey() = true for a key that cannot match the prefix")
+	}
+}
+
+func TestSuffixMatchesAddress(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	addr := oaddr.Encode(pub)
+
+	s, err := NewSuffix(addr[len(addr)-5:])
+	if err != nil {
+		t.Fatalf("NewSuffix() error = %v", err)
+	}
+	if !s.MatchesAddress(addr) {
+		t.Fatal("MatchesAddress() = false for the key that produced the address")
+	}
+}
@@ -0,0 +1,104 @@
+// Package resultfile implements the consolidated single-file output mode,
+// where matches are appended as one line per address instead of one
+// directory per hit.
+package resultfile
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/n0madic/oniongen-go/pkg/oaddr"
+)
+
+// Writer appends matches to a single file, one tab-separated line per
+// address: index, ".onion" hostname, base64-encoded raw public key, and
+// base64-encoded 64-byte expanded secret key.
+type Writer struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// Create opens (or creates) the file at path for appending matches.
+func Create(path string) (*Writer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("resultfile: opening %s: %w", path, err)
+	}
+	return &Writer{f: f}, nil
+}
+
+// WriteMatch appends one line for the given match. Writes are
+// mutex-serialized so concurrent goroutines can share a single Writer.
+func (w *Writer) WriteMatch(index int64, hostname string, pub ed25519.PublicKey, secretKey [64]byte) error {
+	line := fmt.Sprintf("%d\t%s.onion\t%s\t%s\n",
+		index,
+		hostname,
+		base64.StdEncoding.EncodeToString(pub),
+		base64.StdEncoding.EncodeToString(secretKey[:]),
+	)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.f.WriteString(line); err != nil {
+		return fmt.Errorf("resultfile: writing match: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying file.
+func (w *Writer) Close() error {
+	return w.f.Close()
+}
+
+// ParseLine parses one line previously written by WriteMatch, returning
+// the hostname without its ".onion" suffix. The hostname is validated as
+// the genuine v3 onion address for the decoded public key, via
+// oaddr.Parse, rather than trusted as a bare string: callers such as
+// onion-explode use it to build a filesystem path, and a malformed or
+// adversarial line (e.g. a "hostname" of "../../etc") must not be able to
+// smuggle path traversal through here.
+func ParseLine(line string) (index int64, hostname string, pub ed25519.PublicKey, secretKey [64]byte, err error) {
+	fields := strings.Split(strings.TrimRight(line, "\n"), "\t")
+	if len(fields) != 4 {
+		return 0, "", nil, secretKey, fmt.Errorf("resultfile: expected 4 tab-separated fields, got %d", len(fields))
+	}
+
+	index, err = strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, "", nil, secretKey, fmt.Errorf("resultfile: parsing index: %w", err)
+	}
+
+	pubBytes, err := base64.StdEncoding.DecodeString(fields[2])
+	if err != nil {
+		return 0, "", nil, secretKey, fmt.Errorf("resultfile: decoding public key: %w", err)
+	}
+	if len(pubBytes) != ed25519.PublicKeySize {
+		return 0, "", nil, secretKey, fmt.Errorf("resultfile: public key has length %d, want %d", len(pubBytes), ed25519.PublicKeySize)
+	}
+	pub = ed25519.PublicKey(pubBytes)
+
+	addrPub, err := oaddr.Parse(fields[1])
+	if err != nil {
+		return 0, "", nil, secretKey, fmt.Errorf("resultfile: invalid hostname %q: %w", fields[1], err)
+	}
+	if !addrPub.Equal(pub) {
+		return 0, "", nil, secretKey, fmt.Errorf("resultfile: hostname %q does not match the public key on the same line", fields[1])
+	}
+	hostname = strings.TrimSuffix(strings.ToLower(fields[1]), ".onion")
+
+	secretBytes, err := base64.StdEncoding.DecodeString(fields[3])
+	if err != nil {
+		return 0, "", nil, secretKey, fmt.Errorf("resultfile: decoding secret key: %w", err)
+	}
+	if len(secretBytes) != len(secretKey) {
+		return 0, "", nil, secretKey, fmt.Errorf("resultfile: secret key has length %d, want %d", len(secretBytes), len(secretKey))
+	}
+	copy(secretKey[:], secretBytes)
+
+	return index, hostname, pub, secretKey, nil
+}
@@ -0,0 +1,99 @@
+package resultfile
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/n0madic/oniongen-go/pkg/oaddr"
+)
+
+func TestWriteMatchParseLineRoundTrip(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	hostname := oaddr.Encode(pub)
+	var secret [64]byte
+	copy(secret[:], []byte("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcd"))
+
+	path := filepath.Join(t.TempDir(), "results.txt")
+	w, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := w.WriteMatch(7, hostname, pub, secret); err != nil {
+		t.Fatalf("WriteMatch() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("opening %s: %v", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		t.Fatal("expected one line in the result file")
+	}
+
+	index, hostname, gotPub, gotSecret, err := ParseLine(scanner.Text())
+	if err != nil {
+		t.Fatalf("ParseLine() error = %v", err)
+	}
+	if index != 7 {
+		t.Fatalf("index = %d, want 7", index)
+	}
+	if hostname != oaddr.Encode(pub) {
+		t.Fatalf("hostname = %q, want %q", hostname, oaddr.Encode(pub))
+	}
+	if !gotPub.Equal(pub) {
+		t.Fatalf("public key = %x, want %x", gotPub, pub)
+	}
+	if gotSecret != secret {
+		t.Fatal("secret key did not round-trip")
+	}
+}
+
+func TestParseLineRejectsMalformed(t *testing.T) {
+	if _, _, _, _, err := ParseLine("not\tenough\tfields"); err == nil {
+		t.Fatal("ParseLine() accepted a line with too few fields")
+	}
+}
+
+// TestParseLineRejectsPathTraversalHostname guards against a corrupted or
+// adversarial result file smuggling a filesystem path through the
+// hostname field, which callers like onion-explode join onto -output-dir.
+func TestParseLineRejectsPathTraversalHostname(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	var secret [64]byte
+
+	path := filepath.Join(t.TempDir(), "results.txt")
+	w, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := w.WriteMatch(0, "../../../../tmp/evil", pub, secret); err != nil {
+		t.Fatalf("WriteMatch() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+
+	if _, _, _, _, err := ParseLine(string(data)); err == nil {
+		t.Fatal("ParseLine() accepted a hostname that is not a valid onion address")
+	}
+}
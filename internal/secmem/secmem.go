@@ -0,0 +1,30 @@
+// Package secmem provides small helpers for handling secret key material
+// a little more carefully than plain Go slices allow: zeroing buffers in a
+// way the compiler can't optimize away, and best-effort locking of pages
+// that hold secrets out of swap.
+package secmem
+
+import (
+	"runtime"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Zero overwrites b with zeroes. Apart from any unaligned leading and
+// trailing bytes, it stores in aligned 8-byte words via sync/atomic, which
+// (unlike a plain assignment loop) the compiler cannot treat as a dead
+// store and eliminate once b is no longer read, and pins b with
+// runtime.KeepAlive so it can't be collected mid-wipe.
+func Zero(b []byte) {
+	i := 0
+	for ; i < len(b) && uintptr(unsafe.Pointer(&b[i]))%8 != 0; i++ {
+		b[i] = 0
+	}
+	for ; i+8 <= len(b); i += 8 {
+		atomic.StoreUint64((*uint64)(unsafe.Pointer(&b[i])), 0)
+	}
+	for ; i < len(b); i++ {
+		b[i] = 0
+	}
+	runtime.KeepAlive(b)
+}
@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd || openbsd || netbsd)
+
+package secmem
+
+// Lock is a no-op on platforms other than Linux, macOS and the BSDs listed
+// in secmem_unix.go's build constraint.
+func Lock(b []byte) error { return nil }
+
+// Unlock is a no-op on the same platforms as Lock.
+func Unlock(b []byte) error { return nil }
@@ -0,0 +1,18 @@
+package secmem
+
+import "testing"
+
+func TestZero(t *testing.T) {
+	for _, n := range []int{0, 1, 7, 8, 9, 64} {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = 0xAA
+		}
+		Zero(b)
+		for i, v := range b {
+			if v != 0 {
+				t.Fatalf("len %d: byte %d = %#x, want 0", n, i, v)
+			}
+		}
+	}
+}
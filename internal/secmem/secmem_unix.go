@@ -0,0 +1,24 @@
+//go:build linux || darwin || freebsd || openbsd || netbsd
+
+package secmem
+
+import "golang.org/x/sys/unix"
+
+// Lock attempts to mlock b so its pages cannot be paged to swap, where a
+// discarded secret could outlive the process. mlock typically requires
+// either root or a raised RLIMIT_MEMLOCK, so callers should treat a
+// non-nil error as advisory and continue without it.
+func Lock(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+	return unix.Mlock(b)
+}
+
+// Unlock reverses a prior Lock.
+func Unlock(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+	return unix.Munlock(b)
+}
@@ -0,0 +1,128 @@
+// Package vanity implements the mkp224o-style incremental key search used to
+// speed up vanity onion address generation. Instead of calling
+// ed25519.GenerateKey (a full scalar multiplication [a]B) for every
+// candidate, it generates one random keypair and then walks the curve by
+// repeatedly adding the basepoint to the public point and one to the
+// secret scalar, which is roughly the cost of a single point addition.
+package vanity
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"fmt"
+
+	"filippo.io/edwards25519"
+
+	"github.com/n0madic/oniongen-go/internal/secmem"
+)
+
+// reseedInterval bounds how many consecutive candidates are derived from a
+// single seed before a fresh one is drawn, so the nonce embedded in the
+// expanded secret key stays tied to a recent SHA-512 digest. (The scalar
+// itself is kept reduced mod L, so stepping it never overflows.)
+const reseedInterval = 1 << 20
+
+var basepoint = edwards25519.NewGeneratorPoint()
+
+// one is the scalar 1, used to step the secret scalar forward one step per
+// candidate.
+var one = func() *edwards25519.Scalar {
+	oneBytes := make([]byte, 32)
+	oneBytes[0] = 1
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(oneBytes)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}()
+
+// Generator produces a sequence of ed25519 candidate keys by incremental
+// point addition rather than repeated scalar multiplication.
+type Generator struct {
+	seed   [32]byte
+	nonce  [32]byte
+	scalar *edwards25519.Scalar
+	point  *edwards25519.Point
+	since  int
+}
+
+// NewGenerator draws a fresh random seed and derives the first candidate
+// keypair from it. The seed and nonce fields are best-effort mlocked so
+// they can't be paged to swap; Close should be called once the generator
+// is no longer needed to wipe and unlock them.
+func NewGenerator() (*Generator, error) {
+	g := &Generator{}
+	_ = secmem.Lock(g.seed[:])
+	_ = secmem.Lock(g.nonce[:])
+	if err := g.reseed(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// reseed draws a new random seed and rederives the scalar, nonce and point
+// from it, exactly as crypto/ed25519 expands a seed into a private key.
+// The seed and the intermediate SHA-512 digest are wiped once they've been
+// consumed, since neither is needed again.
+func (g *Generator) reseed() error {
+	if _, err := rand.Read(g.seed[:]); err != nil {
+		return fmt.Errorf("vanity: reading random seed: %w", err)
+	}
+
+	hash := sha512.Sum512(g.seed[:])
+	secmem.Zero(g.seed[:])
+
+	scalar, err := edwards25519.NewScalar().SetBytesWithClamping(hash[:32])
+	if err != nil {
+		secmem.Zero(hash[:])
+		return fmt.Errorf("vanity: clamping scalar: %w", err)
+	}
+
+	g.scalar = scalar
+	copy(g.nonce[:], hash[32:])
+	g.point = edwards25519.NewIdentityPoint().ScalarBaseMult(scalar)
+	g.since = 0
+
+	secmem.Zero(hash[:])
+	return nil
+}
+
+// Close wipes and unlocks the generator's remaining secret state. Callers
+// should defer it once they're done with a Generator.
+func (g *Generator) Close() {
+	secmem.Zero(g.seed[:])
+	secmem.Zero(g.nonce[:])
+	_ = secmem.Unlock(g.seed[:])
+	_ = secmem.Unlock(g.nonce[:])
+}
+
+// PublicKey returns the raw 32-byte public key of the current candidate.
+func (g *Generator) PublicKey() []byte {
+	return g.point.Bytes()
+}
+
+// ExpandedSecretKey returns the 64-byte expanded secret key (secret scalar,
+// reduced mod L rather than in clamped form, followed by nonce) for the
+// current candidate, in the same layout Tor stores in hs_ed25519_secret_key.
+func (g *Generator) ExpandedSecretKey() [64]byte {
+	var expanded [64]byte
+	copy(expanded[:32], g.scalar.Bytes())
+	copy(expanded[32:], g.nonce[:])
+	return expanded
+}
+
+// Advance steps the generator to the next candidate: the point is moved by
+// adding the basepoint, and the scalar by adding one, so the pair stays
+// consistent without ever recomputing [a]B from scratch. Every
+// reseedInterval steps it draws a fresh random seed instead, since the
+// nonce must stay derived from a recent SHA-512 digest.
+func (g *Generator) Advance() error {
+	g.since++
+	if g.since >= reseedInterval {
+		return g.reseed()
+	}
+
+	g.point.Add(g.point, basepoint)
+	g.scalar.Add(g.scalar, one)
+	return nil
+}
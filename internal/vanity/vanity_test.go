@@ -0,0 +1,75 @@
+package vanity
+
+import (
+	"bytes"
+	"testing"
+
+	"filippo.io/edwards25519"
+)
+
+// TestGeneratorPublicKeyMatchesScalar checks that the reported public key is
+// actually [scalar]B, i.e. the incremental walk never desyncs the point
+// from the scalar it's supposed to track.
+func TestGeneratorPublicKeyMatchesScalar(t *testing.T) {
+	g, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+
+	for step := 0; step < 3; step++ {
+		want := edwards25519.NewIdentityPoint().ScalarBaseMult(g.scalar).Bytes()
+		got := g.PublicKey()
+		if !bytes.Equal(want, got) {
+			t.Fatalf("step %d: PublicKey() = %x, want %x", step, got, want)
+		}
+		if err := g.Advance(); err != nil {
+			t.Fatalf("Advance() error = %v", err)
+		}
+	}
+}
+
+// TestAdvanceWalksDistinctPoints ensures successive candidates differ.
+func TestAdvanceWalksDistinctPoints(t *testing.T) {
+	g, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+
+	first := append([]byte{}, g.PublicKey()...)
+	if err := g.Advance(); err != nil {
+		t.Fatalf("Advance() error = %v", err)
+	}
+	second := g.PublicKey()
+
+	if bytes.Equal(first, second) {
+		t.Fatal("Advance() produced the same public key as before")
+	}
+}
+
+// TestExpandedSecretKeyLength checks the Tor on-disk layout expectations.
+func TestExpandedSecretKeyLength(t *testing.T) {
+	g, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+
+	expanded := g.ExpandedSecretKey()
+	if len(expanded) != 64 {
+		t.Fatalf("len(ExpandedSecretKey()) = %d, want 64", len(expanded))
+	}
+}
+
+func BenchmarkGeneratorAdvance(b *testing.B) {
+	g, err := NewGenerator()
+	if err != nil {
+		b.Fatalf("NewGenerator() error = %v", err)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = g.PublicKey()
+		if err := g.Advance(); err != nil {
+			b.Fatalf("Advance() error = %v", err)
+		}
+	}
+}
@@ -1,21 +1,23 @@
 package main
 
 import (
-	"bytes"
 	"crypto/ed25519"
-	"crypto/sha512"
-	"encoding/base32"
+	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
 	"runtime"
-	"strconv"
-	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"golang.org/x/crypto/sha3"
+	"github.com/n0madic/oniongen-go/internal/match"
+	"github.com/n0madic/oniongen-go/internal/resultfile"
+	"github.com/n0madic/oniongen-go/internal/secmem"
+	"github.com/n0madic/oniongen-go/internal/vanity"
+	"github.com/n0madic/oniongen-go/pkg/oaddr"
+	"github.com/n0madic/oniongen-go/pkg/okey"
 )
 
 var (
@@ -25,94 +27,180 @@ var (
 
 const batchSize = 10000 // Increased batch size for better performance
 
-// Preallocate buffers for each goroutine
-var bufferPool = sync.Pool{
-	New: func() interface{} {
-		buffer := new(bytes.Buffer)
-		buffer.Grow(100)
-		return buffer
-	},
+// stringSlice collects repeatable flag values, such as multiple -regex
+// flags, into a slice.
+type stringSlice []string
+
+func (s *stringSlice) String() string {
+	return fmt.Sprint([]string(*s))
 }
 
-// generateBatch generates a batch of keys and checks them against the regular expressions
-func generateBatch(wg *sync.WaitGroup, regexps []*regexp.Regexp, resultChan chan<- string, saveWg *sync.WaitGroup) {
-	buffer := bufferPool.Get().(*bytes.Buffer)
-	defer bufferPool.Put(buffer)
+func (s *stringSlice) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
 
-	batch := make([]ed25519.PublicKey, batchSize)
-	batchSecretKeys := make([]ed25519.PrivateKey, batchSize)
+// config holds the parsed command-line options.
+type config struct {
+	matchers  *match.Set
+	count     int
+	threads   int
+	outputDir string
+	// outputFile, when non-empty, switches saving from one Tor-format
+	// directory per hit to a single consolidated file at this path.
+	outputFile string
+	quiet      bool
+}
 
-	for {
-		// Generate a batch of keys
-		for i := 0; i < batchSize; i++ {
-			pub, priv, _ := ed25519.GenerateKey(nil)
-			batch[i] = pub
-			batchSecretKeys[i] = priv
+func parseFlags(args []string) (*config, error) {
+	fs := flag.NewFlagSet("oniongen", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: oniongen [flags]")
+		fs.PrintDefaults()
+	}
+
+	var prefixes, suffixes, regexes stringSlice
+	fs.Var(&prefixes, "prefix", "match addresses starting with this base32 string (repeatable)")
+	fs.Var(&suffixes, "suffix", "match addresses ending with this base32 string (repeatable)")
+	fs.Var(&regexes, "regex", "match addresses against this regular expression (repeatable)")
+	count := fs.Int("count", 1, "number of matching addresses to find")
+	threads := fs.Int("threads", runtime.NumCPU(), "number of generator goroutines to run")
+	outputDir := fs.String("output-dir", ".", "directory under which matches are saved")
+	singleFile := fs.Bool("single-file", false, "append matches to a single consolidated file (see -output-file) instead of one directory per hit")
+	outputFile := fs.String("output-file", "", "path of the consolidated file to append matches to; implies -single-file. Explode it into Tor key directories with onion-explode")
+	quiet := fs.Bool("quiet", false, "suppress progress statistics")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var prefixMatchers []*match.Prefix
+	for _, p := range prefixes {
+		m, err := match.NewPrefix(p)
+		if err != nil {
+			return nil, err
 		}
-		atomic.AddInt64(&generated, batchSize)
+		prefixMatchers = append(prefixMatchers, m)
+	}
+
+	var suffixMatchers []*match.Suffix
+	for _, s := range suffixes {
+		m, err := match.NewSuffix(s)
+		if err != nil {
+			return nil, err
+		}
+		suffixMatchers = append(suffixMatchers, m)
+	}
+
+	var regexps []*regexp.Regexp
+	for _, r := range regexes {
+		re, err := regexp.Compile(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", r, err)
+		}
+		regexps = append(regexps, re)
+	}
+
+	if len(prefixMatchers) == 0 && len(suffixMatchers) == 0 && len(regexps) == 0 {
+		return nil, fmt.Errorf("at least one of -prefix, -suffix or -regex is required")
+	}
+	if *count < 1 {
+		return nil, fmt.Errorf("-count must be at least 1")
+	}
+	if *threads < 1 {
+		return nil, fmt.Errorf("-threads must be at least 1")
+	}
+
+	resolvedOutputFile := *outputFile
+	if resolvedOutputFile == "" && *singleFile {
+		resolvedOutputFile = filepath.Join(*outputDir, "oniongen.txt")
+	}
+
+	return &config{
+		matchers:   match.NewSet(prefixMatchers, suffixMatchers, regexps),
+		count:      *count,
+		threads:    *threads,
+		outputDir:  *outputDir,
+		outputFile: resolvedOutputFile,
+		quiet:      *quiet,
+	}, nil
+}
+
+// generateBatch walks an incremental key sequence, rejecting most
+// candidates with a cheap pre-checksum filter before checking them against
+// the configured matchers. It keeps searching after cfg.count matches have
+// been claimed across all threads, and returns only when it claims a match
+// beyond the count-th (or on a generator error), so it must never call
+// wg.Done() or send on
+// resultChan for a match beyond the count-th: by the time wg.Wait() in main
+// returns, resultChan is closed and wg's counter is at zero, and either
+// would panic.
+func generateBatch(wg *sync.WaitGroup, cfg *config, results *resultfile.Writer, resultChan chan<- string, saveWg *sync.WaitGroup) {
+	gen, err := vanity.NewGenerator()
+	if err != nil {
+		fmt.Printf("Error initializing key generator: %v\n", err)
+		return
+	}
+	defer gen.Close()
 
-		// Check generated keys
-		for i, publicKey := range batch {
-			buffer.Reset()
-			onionAddress := encodePublicKey(publicKey, buffer)
-			for _, re := range regexps {
-				if re.MatchString(onionAddress) {
+	for {
+		for i := 0; i < batchSize; i++ {
+			publicKey := ed25519.PublicKey(gen.PublicKey())
+			if cfg.matchers.MayMatchPublicKey(publicKey) {
+				onionAddress := oaddr.Encode(publicKey)
+				if cfg.matchers.MatchesAddress(onionAddress) {
+					rank := atomic.AddInt64(&found, 1)
+					if rank > int64(cfg.count) {
+						return
+					}
 					resultChan <- onionAddress
 					saveWg.Add(1)
-					go save(onionAddress, publicKey, expandSecretKey(batchSecretKeys[i]), saveWg)
-					atomic.AddInt64(&found, 1)
+					go save(cfg, results, rank, onionAddress, publicKey, gen.ExpandedSecretKey(), saveWg)
 					wg.Done()
-					break
 				}
 			}
+			if err := gen.Advance(); err != nil {
+				fmt.Printf("Error advancing key generator: %v\n", err)
+				return
+			}
 		}
+		atomic.AddInt64(&generated, batchSize)
 	}
 }
 
-// expandSecretKey expands the secret key to 64 bytes
-func expandSecretKey(secretKey ed25519.PrivateKey) [64]byte {
-	hash := sha512.Sum512(secretKey[:32])
-	hash[0] &= 248
-	hash[31] &= 127
-	hash[31] |= 64
-	return hash
-}
+// save stores a matching key either as a single consolidated-file line or
+// as a Tor-format directory, depending on whether cfg.outputFile is set.
+// rank is the value generateBatch's atomic.AddInt64 returned when it
+// claimed this match, i.e. this match's 1-based position among the
+// cfg.count matches the run will keep; reading the shared found counter
+// here instead would race with other threads' claims. secretKey is a
+// by-value copy that lives on this goroutine's stack; it is wiped before
+// returning instead of being left for the garbage collector.
+func save(cfg *config, results *resultfile.Writer, rank int64, onionAddress string, publicKey ed25519.PublicKey, secretKey [64]byte, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer secmem.Zero(secretKey[:])
 
-// encodePublicKey encodes the public key into an onion address
-func encodePublicKey(publicKey ed25519.PublicKey, buffer *bytes.Buffer) string {
-	buffer.Write([]byte(".onion checksum"))
-	buffer.Write(publicKey)
-	buffer.WriteByte(0x03)
-	checksum := sha3.Sum256(buffer.Bytes())
-	buffer.Reset()
-	buffer.Write(publicKey)
-	buffer.Write(checksum[:2])
-	buffer.WriteByte(0x03)
-	return strings.ToLower(base32.StdEncoding.EncodeToString(buffer.Bytes()))
-}
+	if cfg.outputFile != "" {
+		if err := results.WriteMatch(rank, onionAddress, publicKey, secretKey); err != nil {
+			fmt.Printf("Error writing result for %s: %v\n", onionAddress, err)
+		}
+		return
+	}
 
-// save stores the generated keys and address in files
-func save(onionAddress string, publicKey ed25519.PublicKey, secretKey [64]byte, wg *sync.WaitGroup) {
-	defer wg.Done()
-	err := os.MkdirAll(onionAddress, 0700)
-	if err != nil {
-		fmt.Printf("Error creating directory %s: %v\n", onionAddress, err)
+	dir := filepath.Join(cfg.outputDir, onionAddress)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		fmt.Printf("Error creating directory %s: %v\n", dir, err)
 		return
 	}
-	secretKeyFile := append([]byte("== ed25519v1-secret: type0 ==\x00\x00\x00"), secretKey[:]...)
-	err = os.WriteFile(onionAddress+"/hs_ed25519_secret_key", secretKeyFile, 0600)
-	if err != nil {
+	if err := okey.SaveSecretKey(filepath.Join(dir, "hs_ed25519_secret_key"), secretKey); err != nil {
 		fmt.Printf("Error writing secret key file for %s: %v\n", onionAddress, err)
 		return
 	}
-	publicKeyFile := append([]byte("== ed25519v1-public: type0 ==\x00\x00\x00"), publicKey...)
-	err = os.WriteFile(onionAddress+"/hs_ed25519_public_key", publicKeyFile, 0600)
-	if err != nil {
+	if err := okey.SavePublicKey(filepath.Join(dir, "hs_ed25519_public_key"), publicKey); err != nil {
 		fmt.Printf("Error writing public key file for %s: %v\n", onionAddress, err)
 		return
 	}
-	err = os.WriteFile(onionAddress+"/hostname", []byte(onionAddress+".onion\n"), 0600)
-	if err != nil {
+	if err := okey.SaveHostname(filepath.Join(dir, "hostname"), onionAddress); err != nil {
 		fmt.Printf("Error writing hostname file for %s: %v\n", onionAddress, err)
 		return
 	}
@@ -131,53 +219,33 @@ func printStats(startTime time.Time) {
 }
 
 func main() {
-	// Check command-line arguments
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: program <regex1> [<regex2> ...] [<num_addresses>]")
+	cfg, err := parseFlags(os.Args[1:])
+	if err != nil {
+		fmt.Println(err)
 		os.Exit(1)
 	}
 
-	// Parse and compile regular expressions
-	var regexps []*regexp.Regexp
-	numAddresses := 1
-	var err error
-
-	for i := 1; i < len(os.Args); i++ {
-		if i == len(os.Args)-1 {
-			// Check if the last argument is a number
-			numAddresses, err = strconv.Atoi(os.Args[i])
-			if err == nil {
-				break
-			}
-		}
-		re, err := regexp.Compile(os.Args[i])
+	var results *resultfile.Writer
+	if cfg.outputFile != "" {
+		results, err = resultfile.Create(cfg.outputFile)
 		if err != nil {
-			fmt.Printf("Invalid regex '%s': %v\n", os.Args[i], err)
+			fmt.Println(err)
 			os.Exit(1)
 		}
-		regexps = append(regexps, re)
-	}
-
-	if len(regexps) == 0 {
-		fmt.Println("At least one valid regex is required")
-		os.Exit(1)
+		defer results.Close()
 	}
 
 	var wg sync.WaitGroup
 	var saveWg sync.WaitGroup
-	if numAddresses < 1 {
-		numAddresses = 1
-	}
-	wg.Add(numAddresses)
-	resultChan := make(chan string, numAddresses)
+	wg.Add(cfg.count)
+	resultChan := make(chan string, cfg.count)
 
-	// Start a goroutine to print statistics
-	go printStats(time.Now())
+	if !cfg.quiet {
+		go printStats(time.Now())
+	}
 
-	// Start goroutines for address generation
-	numCPU := runtime.NumCPU()
-	for i := 0; i < numCPU; i++ {
-		go generateBatch(&wg, regexps, resultChan, &saveWg)
+	for i := 0; i < cfg.threads; i++ {
+		go generateBatch(&wg, cfg, results, resultChan, &saveWg)
 	}
 
 	// Goroutine to print found addresses
@@ -0,0 +1,68 @@
+// Package oaddr encodes and parses Tor v3 onion addresses.
+package oaddr
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base32"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	version        = 0x03
+	checksumPrefix = ".onion checksum"
+	addressLen     = ed25519.PublicKeySize + 2 + 1
+)
+
+// Encode returns the lowercase 56-character v3 onion address, without the
+// ".onion" suffix, for pub.
+func Encode(pub ed25519.PublicKey) string {
+	var buf bytes.Buffer
+	buf.WriteString(checksumPrefix)
+	buf.Write(pub)
+	buf.WriteByte(version)
+	checksum := sha3.Sum256(buf.Bytes())
+
+	buf.Reset()
+	buf.Write(pub)
+	buf.Write(checksum[:2])
+	buf.WriteByte(version)
+	return strings.ToLower(base32.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+// Parse decodes a v3 onion address, with or without its ".onion" suffix,
+// verifies the version byte and the two-byte SHA3-256 checksum, and
+// returns the public key it encodes.
+func Parse(addr string) (ed25519.PublicKey, error) {
+	addr = strings.TrimSuffix(strings.ToLower(addr), ".onion")
+
+	decoded, err := base32.StdEncoding.DecodeString(strings.ToUpper(addr))
+	if err != nil {
+		return nil, fmt.Errorf("oaddr: decoding address: %w", err)
+	}
+	if len(decoded) != addressLen {
+		return nil, fmt.Errorf("oaddr: invalid address length %d, want %d", len(decoded), addressLen)
+	}
+
+	pub := ed25519.PublicKey(append([]byte(nil), decoded[:ed25519.PublicKeySize]...))
+	checksum := decoded[ed25519.PublicKeySize : ed25519.PublicKeySize+2]
+	versionByte := decoded[ed25519.PublicKeySize+2]
+
+	if versionByte != version {
+		return nil, fmt.Errorf("oaddr: unsupported version byte 0x%02x", versionByte)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(checksumPrefix)
+	buf.Write(pub)
+	buf.WriteByte(version)
+	want := sha3.Sum256(buf.Bytes())
+	if !bytes.Equal(want[:2], checksum) {
+		return nil, fmt.Errorf("oaddr: checksum mismatch")
+	}
+
+	return pub, nil
+}
@@ -0,0 +1,51 @@
+package oaddr
+
+import (
+	"crypto/ed25519"
+	"testing"
+)
+
+func TestEncodeParseRoundTrip(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	addr := Encode(pub)
+	if len(addr) != 56 {
+		t.Fatalf("Encode() len = %d, want 56", len(addr))
+	}
+
+	got, err := Parse(addr + ".onion")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !got.Equal(pub) {
+		t.Fatalf("Parse() = %x, want %x", got, pub)
+	}
+}
+
+func TestParseRejectsBadChecksum(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	addr := Encode(pub)
+
+	tampered := []byte(addr)
+	if tampered[0] == 'a' {
+		tampered[0] = 'b'
+	} else {
+		tampered[0] = 'a'
+	}
+
+	if _, err := Parse(string(tampered)); err == nil {
+		t.Fatal("Parse() of a tampered address returned no error")
+	}
+}
+
+func TestParseRejectsWrongLength(t *testing.T) {
+	if _, err := Parse("short"); err == nil {
+		t.Fatal("Parse() of a too-short address returned no error")
+	}
+}
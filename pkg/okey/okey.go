@@ -0,0 +1,124 @@
+// Package okey reads and writes the key files Tor stores in a hidden
+// service's data directory (hs_ed25519_secret_key, hs_ed25519_public_key
+// and hostname).
+package okey
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"strings"
+
+	"filippo.io/edwards25519"
+
+	"github.com/n0madic/oniongen-go/internal/secmem"
+	"github.com/n0madic/oniongen-go/pkg/oaddr"
+)
+
+const (
+	secretKeyHeader = "== ed25519v1-secret: type0 ==\x00\x00\x00"
+	publicKeyHeader = "== ed25519v1-public: type0 ==\x00\x00\x00"
+)
+
+// SaveSecretKey writes the 64-byte expanded secret key (clamped scalar
+// followed by nonce) to path in Tor's hs_ed25519_secret_key format. The
+// header+key buffer it builds is wiped before returning, since it's a
+// second heap copy of the secret that callers zeroing their own copy of
+// expanded have no way to reach.
+func SaveSecretKey(path string, expanded [64]byte) error {
+	data := append([]byte(secretKeyHeader), expanded[:]...)
+	defer secmem.Zero(data)
+	return os.WriteFile(path, data, 0600)
+}
+
+// SavePublicKey writes pub to path in Tor's hs_ed25519_public_key format.
+func SavePublicKey(path string, pub ed25519.PublicKey) error {
+	data := append([]byte(publicKeyHeader), pub...)
+	return os.WriteFile(path, data, 0600)
+}
+
+// SaveHostname writes address (without the ".onion" suffix) to path in
+// Tor's hostname format.
+func SaveHostname(path string, address string) error {
+	return os.WriteFile(path, []byte(address+".onion\n"), 0600)
+}
+
+// LoadSecretKey reads a Tor hs_ed25519_secret_key file at path and returns
+// the raw 64-byte expanded secret key, with the on-disk header stripped.
+func LoadSecretKey(path string) (expanded [64]byte, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return expanded, fmt.Errorf("okey: reading %s: %w", path, err)
+	}
+	if len(data) != len(secretKeyHeader)+64 || string(data[:len(secretKeyHeader)]) != secretKeyHeader {
+		return expanded, fmt.Errorf("okey: %s is not a valid ed25519v1 secret key file", path)
+	}
+	copy(expanded[:], data[len(secretKeyHeader):])
+	return expanded, nil
+}
+
+// LoadPublicKey reads a Tor hs_ed25519_public_key file at path and returns
+// the raw 32-byte public key, with the on-disk header stripped.
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("okey: reading %s: %w", path, err)
+	}
+	if len(data) != len(publicKeyHeader)+ed25519.PublicKeySize || string(data[:len(publicKeyHeader)]) != publicKeyHeader {
+		return nil, fmt.Errorf("okey: %s is not a valid ed25519v1 public key file", path)
+	}
+	pub := make(ed25519.PublicKey, ed25519.PublicKeySize)
+	copy(pub, data[len(publicKeyHeader):])
+	return pub, nil
+}
+
+// LoadHostname reads a Tor hostname file at path and returns the onion
+// address it contains, without the trailing newline.
+func LoadHostname(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("okey: reading %s: %w", path, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// pointFromScalar derives the public point [a]B for the scalar stored in
+// the first 32 bytes of an expanded secret key. The scalar is treated as
+// already final: it is wide-reduced mod L via SetUniformBytes rather than
+// re-clamped, since SetBytesWithClamping's cofactor-clearing bit-twiddling
+// only applies to a raw, unreduced 32-byte value and corrupts a Scalar
+// that has already been reduced (as vanity.Generator's are). A classic
+// clamped-but-unreduced scalar works through the same call too: clamping
+// sets bit 254, which only pushes the value above L for SetUniformBytes to
+// reduce back down, recovering the same result SetBytesWithClamping's own
+// narrow reduction would have produced.
+func pointFromScalar(expanded [64]byte) (*edwards25519.Point, error) {
+	var wide [64]byte
+	copy(wide[:32], expanded[:32])
+	scalar, err := edwards25519.NewScalar().SetUniformBytes(wide[:])
+	if err != nil {
+		return nil, fmt.Errorf("okey: invalid secret key scalar: %w", err)
+	}
+	return edwards25519.NewIdentityPoint().ScalarBaseMult(scalar), nil
+}
+
+// Verify checks that secretKey's scalar derives pub, and that pub encodes
+// to hostname (with or without its ".onion" suffix). It returns a non-nil
+// error describing the first mismatch found.
+func Verify(secretKey [64]byte, pub ed25519.PublicKey, hostname string) error {
+	point, err := pointFromScalar(secretKey)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(point.Bytes(), pub) {
+		return fmt.Errorf("okey: secret key does not derive the given public key")
+	}
+
+	wantHostname := strings.TrimSuffix(strings.ToLower(hostname), ".onion")
+	if oaddr.Encode(pub) != wantHostname {
+		return fmt.Errorf("okey: public key does not encode to hostname %q", hostname)
+	}
+
+	return nil
+}
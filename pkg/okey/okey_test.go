@@ -0,0 +1,81 @@
+package okey
+
+import (
+	"crypto/ed25519"
+	"path/filepath"
+	"testing"
+
+	"github.com/n0madic/oniongen-go/internal/vanity"
+	"github.com/n0madic/oniongen-go/pkg/oaddr"
+)
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	gen, err := vanity.NewGenerator()
+	if err != nil {
+		t.Fatalf("vanity.NewGenerator() error = %v", err)
+	}
+	pub := ed25519.PublicKey(gen.PublicKey())
+	secret := gen.ExpandedSecretKey()
+	hostname := oaddr.Encode(pub)
+
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "hs_ed25519_secret_key")
+	publicPath := filepath.Join(dir, "hs_ed25519_public_key")
+	hostnamePath := filepath.Join(dir, "hostname")
+
+	if err := SaveSecretKey(secretPath, secret); err != nil {
+		t.Fatalf("SaveSecretKey() error = %v", err)
+	}
+	if err := SavePublicKey(publicPath, pub); err != nil {
+		t.Fatalf("SavePublicKey() error = %v", err)
+	}
+	if err := SaveHostname(hostnamePath, hostname); err != nil {
+		t.Fatalf("SaveHostname() error = %v", err)
+	}
+
+	loadedSecret, err := LoadSecretKey(secretPath)
+	if err != nil {
+		t.Fatalf("LoadSecretKey() error = %v", err)
+	}
+	if loadedSecret != secret {
+		t.Fatal("LoadSecretKey() did not round-trip")
+	}
+
+	loadedPub, err := LoadPublicKey(publicPath)
+	if err != nil {
+		t.Fatalf("LoadPublicKey() error = %v", err)
+	}
+	if !loadedPub.Equal(pub) {
+		t.Fatalf("LoadPublicKey() = %x, want %x", loadedPub, pub)
+	}
+
+	loadedHostname, err := LoadHostname(hostnamePath)
+	if err != nil {
+		t.Fatalf("LoadHostname() error = %v", err)
+	}
+	if loadedHostname != hostname+".onion" {
+		t.Fatalf("LoadHostname() = %q, want %q", loadedHostname, hostname+".onion")
+	}
+
+	if err := Verify(loadedSecret, loadedPub, loadedHostname); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+}
+
+func TestVerifyRejectsMismatchedPublicKey(t *testing.T) {
+	gen, err := vanity.NewGenerator()
+	if err != nil {
+		t.Fatalf("vanity.NewGenerator() error = %v", err)
+	}
+	secret := gen.ExpandedSecretKey()
+
+	other, err := vanity.NewGenerator()
+	if err != nil {
+		t.Fatalf("vanity.NewGenerator() error = %v", err)
+	}
+	mismatchedPub := other.PublicKey()
+
+	if err := Verify(secret, mismatchedPub, oaddr.Encode(mismatchedPub)); err == nil {
+		t.Fatal("Verify() accepted a public key the secret key does not derive")
+	}
+}